@@ -0,0 +1,182 @@
+package secure
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+const (
+	originHeader                = "Origin"
+	varyHeader                  = "Vary"
+	accessControlRequestMethod  = "Access-Control-Request-Method"
+	accessControlRequestHeaders = "Access-Control-Request-Headers"
+	allowOriginHeader           = "Access-Control-Allow-Origin"
+	allowMethodsHeader          = "Access-Control-Allow-Methods"
+	allowHeadersHeader          = "Access-Control-Allow-Headers"
+	exposeHeadersHeader         = "Access-Control-Expose-Headers"
+	allowCredentialsHeader      = "Access-Control-Allow-Credentials"
+	maxAgeHeader                = "Access-Control-Max-Age"
+)
+
+// CORSConfig configures the Cross-Origin Resource Sharing behaviour added
+// by WithCORS. AllowedOrigins entries may be an exact origin
+// ("https://example.com"), a wildcard subdomain ("https://*.example.com"),
+// or the literal "*" to allow any origin. Set AllowOriginRegex instead to
+// match origins with a regular expression; when both are set, an exact or
+// wildcard match is tried first.
+type CORSConfig struct {
+	// AllowedOrigins is the list of origins allowed to make cross-origin
+	// requests. Default is empty, which allows none.
+	AllowedOrigins []string
+	// AllowOriginRegex, when non-empty, matches the Origin header against
+	// this regular expression as an alternative to AllowedOrigins.
+	AllowOriginRegex string
+	// AllowedMethods is the list of methods advertised in
+	// Access-Control-Allow-Methods for preflight requests.
+	AllowedMethods []string
+	// AllowedHeaders is the list of headers advertised in
+	// Access-Control-Allow-Headers for preflight requests.
+	AllowedHeaders []string
+	// ExposedHeaders is the list of headers advertised in
+	// Access-Control-Expose-Headers on actual responses.
+	ExposedHeaders []string
+	// AllowCredentials, when true, sets Access-Control-Allow-Credentials
+	// to "true". Browsers reject a literal "*" origin alongside it, so a
+	// "*" AllowedOrigins entry then reflects the request's Origin instead.
+	AllowCredentials bool
+	// MaxAge is the value, in seconds, of Access-Control-Max-Age on
+	// preflight responses. A zero value omits the header.
+	MaxAge int64
+}
+
+// handleCORSPreflight answers a CORS preflight request directly and
+// reports whether it did so. A request is treated as a preflight when its
+// method is OPTIONS and it carries an Access-Control-Request-Method
+// header, per the Fetch standard.
+func (p *policy) handleCORSPreflight(c *app.RequestContext) bool {
+	if string(c.Request.Method()) != consts.MethodOptions {
+		return false
+	}
+	if len(c.Request.Header.Get(accessControlRequestMethod)) == 0 {
+		return false
+	}
+
+	origin := c.Request.Header.Get(originHeader)
+	allowOrigin, ok := p.matchCORSOrigin(origin)
+	if !ok {
+		c.AbortWithStatus(consts.StatusForbidden)
+		return true
+	}
+
+	header := &c.Response.Header
+	header.Set(allowOriginHeader, allowOrigin)
+	if allowOrigin != "*" {
+		header.Add(varyHeader, originHeader)
+	}
+	if p.opt.CORS.AllowCredentials {
+		header.Set(allowCredentialsHeader, "true")
+	}
+	if len(p.opt.CORS.AllowedMethods) > 0 {
+		header.Set(allowMethodsHeader, strings.Join(p.opt.CORS.AllowedMethods, ", "))
+	}
+	if len(p.opt.CORS.AllowedHeaders) > 0 {
+		header.Set(allowHeadersHeader, strings.Join(p.opt.CORS.AllowedHeaders, ", "))
+	} else if reqHeaders := c.Request.Header.Get(accessControlRequestHeaders); reqHeaders != "" {
+		header.Set(allowHeadersHeader, reqHeaders)
+	}
+	if p.opt.CORS.MaxAge > 0 {
+		header.Set(maxAgeHeader, strconv.FormatInt(p.opt.CORS.MaxAge, 10))
+	}
+
+	c.AbortWithStatus(consts.StatusNoContent)
+	return true
+}
+
+// applyCORSHeaders adds the Access-Control-* headers relevant to an actual
+// (non-preflight) response, once the request's Origin has been validated
+// against the configured allowlist.
+func (p *policy) applyCORSHeaders(c *app.RequestContext) {
+	origin := c.Request.Header.Get(originHeader)
+	if origin == "" {
+		return
+	}
+	allowOrigin, ok := p.matchCORSOrigin(origin)
+	if !ok {
+		return
+	}
+
+	header := &c.Response.Header
+	header.Set(allowOriginHeader, allowOrigin)
+	if allowOrigin != "*" {
+		header.Add(varyHeader, originHeader)
+	}
+	if p.opt.CORS.AllowCredentials {
+		header.Set(allowCredentialsHeader, "true")
+	}
+	if len(p.opt.CORS.ExposedHeaders) > 0 {
+		header.Set(exposeHeadersHeader, strings.Join(p.opt.CORS.ExposedHeaders, ", "))
+	}
+}
+
+// matchCORSOrigin reports whether origin is allowed and, if so, the value
+// to use for Access-Control-Allow-Origin. A wildcard allowlist entry
+// normally returns "*", but browsers reject "*" alongside
+// Access-Control-Allow-Credentials: true, so credentialed requests get the
+// concrete origin reflected back instead (with the caller adding
+// Vary: Origin, same as any other non-wildcard match).
+func (p *policy) matchCORSOrigin(origin string) (string, bool) {
+	if origin == "" {
+		return "", false
+	}
+
+	for _, allowed := range p.opt.CORS.AllowedOrigins {
+		if allowed == "*" {
+			if p.opt.CORS.AllowCredentials {
+				return origin, true
+			}
+			return "*", true
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin, true
+		}
+		if strings.Contains(allowed, "*") && matchWildcardOrigin(allowed, origin) {
+			return origin, true
+		}
+	}
+
+	if p.corsOriginRegex != nil && p.corsOriginRegex.MatchString(origin) {
+		return origin, true
+	}
+
+	return "", false
+}
+
+// matchWildcardOrigin matches origin against a "*.example.com"-style
+// pattern by host suffix. When the pattern carries a scheme, it must match
+// the origin's scheme (case-insensitively); the origin's port is ignored.
+func matchWildcardOrigin(pattern, origin string) bool {
+	schemeSep := strings.Index(origin, "://")
+	if schemeSep == -1 {
+		return false
+	}
+	scheme, hostport := origin[:schemeSep], origin[schemeSep+3:]
+
+	patternHost := pattern
+	if idx := strings.Index(pattern, "://"); idx != -1 {
+		patternScheme := pattern[:idx]
+		if !strings.EqualFold(patternScheme, scheme) {
+			return false
+		}
+		patternHost = pattern[idx+3:]
+	}
+
+	suffix := strings.TrimPrefix(patternHost, "*")
+	host := hostport
+	if idx := strings.LastIndex(hostport, ":"); idx != -1 {
+		host = hostport[:idx]
+	}
+	return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+}
@@ -0,0 +1,45 @@
+package secure
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+)
+
+// cspNoncePlaceholder is substituted with the per-request nonce anywhere it
+// appears in a configured Content-Security-Policy value.
+const cspNoncePlaceholder = "$NONCE"
+
+// cspNonceContextKey is the key the nonce is stashed under on
+// app.RequestContext via c.Set/c.Value.
+const cspNonceContextKey = "cspNonce"
+
+// cspNonceCtxKey is the unexported context.Context key the nonce is stashed
+// under, so it can't collide with keys set by other packages.
+type cspNonceCtxKey struct{}
+
+// newCSPNonce generates a fresh, base64-encoded, 16-byte random nonce for
+// use in a Content-Security-Policy header.
+func newCSPNonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b) // crypto/rand.Read only fails if the OS CSPRNG is broken
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// substituteCSPNonce replaces cspNoncePlaceholder in policy with nonce. If
+// nonce is empty (nonce generation is disabled), policy is returned as-is.
+func substituteCSPNonce(policy, nonce string) string {
+	if nonce == "" {
+		return policy
+	}
+	return strings.ReplaceAll(policy, cspNoncePlaceholder, nonce)
+}
+
+// CSPNonce returns the per-request CSP nonce stashed on ctx by the secure
+// middleware when WithContentSecurityPolicyNonce is enabled, or "" if none
+// was generated for this request.
+func CSPNonce(ctx context.Context) string {
+	nonce, _ := ctx.Value(cspNonceCtxKey{}).(string)
+	return nonce
+}
@@ -0,0 +1,113 @@
+package secure
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// ReportPayload is the parsed form of a single CSP violation report,
+// regardless of whether it arrived as a legacy application/csp-report
+// object or a Reporting API application/reports+json array entry.
+type ReportPayload struct {
+	DocumentURI       string `json:"document-uri"`
+	ViolatedDirective string `json:"violated-directive"`
+	BlockedURI        string `json:"blocked-uri"`
+	SourceFile        string `json:"source-file"`
+	LineNumber        int    `json:"line-number"`
+	ColumnNumber      int    `json:"column-number"`
+	Disposition       string `json:"disposition"`
+}
+
+// legacyCSPReport is the body shape sent by browsers still using the
+// deprecated `report-uri` directive with Content-Type: application/csp-report.
+type legacyCSPReport struct {
+	CSPReport ReportPayload `json:"csp-report"`
+}
+
+// reportingAPIEntry is a single element of the array body sent by browsers
+// using the `report-to` directive with Content-Type: application/reports+json.
+type reportingAPIEntry struct {
+	Type string `json:"type"`
+	Body struct {
+		DocumentURL       string `json:"documentURL"`
+		ViolatedDirective string `json:"violatedDirective"`
+		BlockedURL        string `json:"blockedURL"`
+		SourceFile        string `json:"sourceFile"`
+		LineNumber        int    `json:"lineNumber"`
+		ColumnNumber      int    `json:"columnNumber"`
+		Disposition       string `json:"disposition"`
+	} `json:"body"`
+}
+
+// parseCSPReports extracts the reported violations from body, dispatching
+// on contentType between the legacy single-object application/csp-report
+// format and the Reporting API's application/reports+json array format.
+// Malformed bodies yield no reports rather than an error, since there is
+// nothing more useful a reporting endpoint can do with them.
+func parseCSPReports(contentType string, body []byte) []*ReportPayload {
+	if strings.Contains(contentType, "application/reports+json") {
+		var entries []reportingAPIEntry
+		if err := json.Unmarshal(body, &entries); err != nil {
+			return nil
+		}
+		reports := make([]*ReportPayload, 0, len(entries))
+		for _, entry := range entries {
+			if entry.Type != "" && entry.Type != "csp-violation" {
+				continue
+			}
+			reports = append(reports, &ReportPayload{
+				DocumentURI:       entry.Body.DocumentURL,
+				ViolatedDirective: entry.Body.ViolatedDirective,
+				BlockedURI:        entry.Body.BlockedURL,
+				SourceFile:        entry.Body.SourceFile,
+				LineNumber:        entry.Body.LineNumber,
+				ColumnNumber:      entry.Body.ColumnNumber,
+				Disposition:       entry.Body.Disposition,
+			})
+		}
+		return reports
+	}
+
+	var legacy legacyCSPReport
+	if err := json.Unmarshal(body, &legacy); err != nil {
+		return nil
+	}
+	return []*ReportPayload{&legacy.CSPReport}
+}
+
+// ReportURIHandler returns a Hertz handler that parses incoming CSP
+// violation reports in either the legacy application/csp-report format or
+// the Reporting API's application/reports+json format, and invokes cb once
+// per reported violation. Register it at whatever path the configured
+// report-uri/Report-To endpoint points to:
+//
+//	router.POST("/csp-report", secure.ReportURIHandler(myCallback))
+//
+// WithReportURI wires this same parsing into the secure middleware itself
+// at a configurable path, so most users won't need to call this directly.
+func ReportURIHandler(cb func(context.Context, *ReportPayload)) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		for _, report := range parseCSPReports(string(c.Request.Header.ContentType()), c.Request.Body()) {
+			cb(ctx, report)
+		}
+		c.AbortWithStatus(consts.StatusNoContent)
+	}
+}
+
+// handleReportURI answers a request to the configured ReportURIPath and
+// reports whether it did so, mirroring handleCORSPreflight's short-circuit
+// pattern: callers should skip the rest of the chain when this returns true.
+func (p *policy) handleReportURI(ctx context.Context, c *app.RequestContext) bool {
+	if string(c.Request.URI().Path()) != p.opt.ReportURIPath {
+		return false
+	}
+	for _, report := range parseCSPReports(string(c.Request.Header.ContentType()), c.Request.Body()) {
+		p.opt.ReportURICallback(ctx, report)
+	}
+	c.AbortWithStatus(consts.StatusNoContent)
+	return true
+}
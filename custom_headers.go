@@ -0,0 +1,29 @@
+package secure
+
+import "github.com/cloudwego/hertz/pkg/app"
+
+// applyCustomRequestHeaders sets or deletes the configured
+// CustomRequestHeaders on the inbound request, before the handler chain
+// continues.
+func (p *policy) applyCustomRequestHeaders(c *app.RequestContext) {
+	for k, v := range p.opt.CustomRequestHeaders {
+		if v == "" {
+			c.Request.Header.Del(k)
+		} else {
+			c.Request.Header.Set(k, v)
+		}
+	}
+}
+
+// applyCustomResponseHeaders sets or deletes the configured
+// CustomResponseHeaders on the outbound response, after the handler chain
+// has run.
+func (p *policy) applyCustomResponseHeaders(c *app.RequestContext) {
+	for k, v := range p.opt.CustomResponseHeaders {
+		if v == "" {
+			c.Response.Header.Del(k)
+		} else {
+			c.Response.Header.Set(k, v)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package secure
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	publicKeyPinsHeader = "Public-Key-Pins"
+	expectCTHeader      = "Expect-CT"
+)
+
+// PublicKeyConfig is a structured alternative to passing a pre-built
+// Public-Key-Pins header value to WithPublicKey. Use BuildPublicKeyPins to
+// turn it into the header value understood by WithPublicKey.
+type PublicKeyConfig struct {
+	// Pins is the list of base64 SHA-256 pin values; each is emitted as a
+	// `pin-sha256="<pin>"` directive. Required.
+	Pins []string
+	// MaxAge is the `max-age` directive, in seconds.
+	MaxAge int64
+	// IncludeSubDomains appends the `includeSubDomains` directive when true.
+	IncludeSubDomains bool
+	// ReportURI, when non-empty, is emitted as the `report-uri` directive.
+	ReportURI string
+}
+
+// BuildPublicKeyPins assembles a Public-Key-Pins header value from cfg,
+// suitable for passing to WithPublicKey.
+func BuildPublicKeyPins(cfg PublicKeyConfig) string {
+	directives := make([]string, 0, len(cfg.Pins)+3)
+	for _, pin := range cfg.Pins {
+		directives = append(directives, `pin-sha256="`+pin+`"`)
+	}
+	directives = append(directives, "max-age="+strconv.FormatInt(cfg.MaxAge, 10))
+	if cfg.IncludeSubDomains {
+		directives = append(directives, "includeSubDomains")
+	}
+	if cfg.ReportURI != "" {
+		directives = append(directives, `report-uri="`+cfg.ReportURI+`"`)
+	}
+	return strings.Join(directives, "; ")
+}
+
+// WithPublicKey sets the Public-Key-Pins header value. s may be a
+// pre-built header value, or the output of BuildPublicKeyPins. The header
+// is only emitted on responses that are served over HTTPS (directly or via
+// SSLProxyHeaders) and is skipped entirely in development mode. Only the
+// development-mode gate is shared with STSSeconds, whose header is emitted
+// regardless of scheme.
+func WithPublicKey(s string) Option {
+	return func(c *Config) {
+		c.PublicKey = s
+	}
+}
+
+// WithExpectCT sets the Expect-CT header value, e.g.
+// `max-age=86400, enforce, report-uri="https://example.com/report"`. Like
+// WithPublicKey, it is only emitted over HTTPS and is skipped in
+// development mode.
+func WithExpectCT(s string) Option {
+	return func(c *Config) {
+		c.ExpectCT = s
+	}
+}
@@ -0,0 +1,210 @@
+package secure
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+const (
+	stsHeader            = "Strict-Transport-Security"
+	stsSubdomainString   = "; includeSubdomains"
+	stsPreloadString     = "; preload"
+	frameOptionsHeader   = "X-Frame-Options"
+	frameOptionsValue    = "DENY"
+	contentTypeHeader    = "X-Content-Type-Options"
+	contentTypeValue     = "nosniff"
+	xssProtectionHeader  = "X-XSS-Protection"
+	xssProtectionValue   = "1; mode=block"
+	cspHeader            = "Content-Security-Policy"
+	cspReportOnlyHeader  = "Content-Security-Policy-Report-Only"
+	reportToHeader       = "Report-To"
+	referrerPolicyHeader = "Referrer-Policy"
+	featurePolicyHeader  = "Feature-Policy"
+	ieNoOpenHeader       = "X-Download-Options"
+	ieNoOpenValue        = "noopen"
+)
+
+// policy evaluates a fully resolved Config against every request it sees,
+// writing the configured security headers and (when necessary)
+// short-circuiting the chain with a redirect or rejection.
+type policy struct {
+	opt Config
+
+	// corsOriginRegex is the compiled form of opt.CORS.AllowOriginRegex,
+	// precompiled once so the CORS hot path never calls regexp.Compile.
+	corsOriginRegex *regexp.Regexp
+}
+
+// newPolicy builds a policy from the Config passed to New/Default.
+func newPolicy(cfg Config) *policy {
+	p := &policy{opt: cfg}
+	if cfg.CORS != nil && cfg.CORS.AllowOriginRegex != "" {
+		// Compilation failures are treated as "never matches" rather than
+		// a panic, since Config has no error return path.
+		p.corsOriginRegex, _ = regexp.Compile(cfg.CORS.AllowOriginRegex)
+	}
+	return p
+}
+
+// isSSL reports whether the current request is effectively secure, either
+// because it arrived over TLS directly or because one of the configured
+// SSLProxyHeaders indicates the TLS termination happened upstream.
+func (p *policy) isSSL(c *app.RequestContext) bool {
+	if string(c.Request.URI().Scheme()) == "https" {
+		return true
+	}
+	for k, v := range p.opt.SSLProxyHeaders {
+		if string(c.Request.Header.Get(k)) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// applyToContext runs all configured checks and writes the resulting
+// headers. It returns false when the request has already been fully
+// handled (redirected or rejected) and the handler chain must stop.
+func (p *policy) applyToContext(ctx context.Context, c *app.RequestContext) (context.Context, bool) {
+	if p.opt.IsDevelopment {
+		return ctx, true
+	}
+
+	host := string(c.Request.Host())
+
+	if len(p.opt.AllowedHosts) > 0 {
+		isGoodHost := false
+		for _, allowedHost := range p.opt.AllowedHosts {
+			if strings.EqualFold(allowedHost, host) {
+				isGoodHost = true
+				break
+			}
+		}
+		if !isGoodHost {
+			if p.opt.BadHostHandler != nil {
+				p.opt.BadHostHandler(ctx, c)
+			} else {
+				c.AbortWithStatus(consts.StatusForbidden)
+			}
+			return ctx, false
+		}
+	}
+
+	ssl := p.isSSL(c)
+
+	if p.opt.SSLRedirect && !ssl && !(p.opt.DontRedirectIPV4Hostnames && isIPV4(host)) {
+		sslHost := p.opt.SSLHost
+		if sslHost == "" {
+			sslHost = host
+		}
+		url := c.URI()
+		url.SetScheme("https")
+		url.SetHost(sslHost)
+
+		status := consts.StatusMovedPermanently
+		if p.opt.SSLTemporaryRedirect {
+			status = consts.StatusFound
+		}
+		c.Redirect(status, url.FullURI())
+		c.Abort()
+		return ctx, false
+	}
+
+	if ssl && p.opt.SSLForceHost && p.opt.SSLHost != "" && !strings.EqualFold(host, p.opt.SSLHost) {
+		url := c.URI()
+		url.SetScheme("https")
+		url.SetHost(p.opt.SSLHost)
+
+		status := consts.StatusMovedPermanently
+		if p.opt.SSLTemporaryRedirect {
+			status = consts.StatusFound
+		}
+		c.Redirect(status, url.FullURI())
+		c.Abort()
+		return ctx, false
+	}
+
+	if p.opt.STSSeconds != 0 && !p.opt.IsDevelopment {
+		sts := "max-age=" + strconv.FormatInt(p.opt.STSSeconds, 10)
+		if p.opt.STSIncludeSubdomains {
+			sts += stsSubdomainString
+		}
+		if p.opt.STSPreload {
+			sts += stsPreloadString
+		}
+		c.Response.Header.Set(stsHeader, sts)
+	}
+
+	if ssl && !p.opt.IsDevelopment {
+		if p.opt.PublicKey != "" {
+			c.Response.Header.Set(publicKeyPinsHeader, p.opt.PublicKey)
+		}
+		if p.opt.ExpectCT != "" {
+			c.Response.Header.Set(expectCTHeader, p.opt.ExpectCT)
+		}
+	}
+
+	if p.opt.FrameDeny {
+		c.Response.Header.Set(frameOptionsHeader, frameOptionsValue)
+	}
+	if p.opt.CustomFrameOptionsValue != "" {
+		c.Response.Header.Set(frameOptionsHeader, p.opt.CustomFrameOptionsValue)
+	}
+
+	if p.opt.ContentTypeNosniff {
+		c.Response.Header.Set(contentTypeHeader, contentTypeValue)
+	}
+
+	if p.opt.BrowserXssFilter {
+		c.Response.Header.Set(xssProtectionHeader, xssProtectionValue)
+	}
+
+	if p.opt.ContentSecurityPolicy != "" || p.opt.ContentSecurityPolicyReportOnly != "" {
+		nonce := ""
+		if p.opt.ContentSecurityPolicyNonce {
+			nonce = newCSPNonce()
+			c.Set(cspNonceContextKey, nonce)
+			ctx = context.WithValue(ctx, cspNonceCtxKey{}, nonce)
+		}
+
+		if p.opt.ContentSecurityPolicy != "" {
+			c.Response.Header.Set(cspHeader, substituteCSPNonce(p.opt.ContentSecurityPolicy, nonce))
+		}
+		if p.opt.ContentSecurityPolicyReportOnly != "" {
+			c.Response.Header.Set(cspReportOnlyHeader, substituteCSPNonce(p.opt.ContentSecurityPolicyReportOnly, nonce))
+		}
+	}
+
+	if p.opt.ReportTo != "" {
+		c.Response.Header.Set(reportToHeader, p.opt.ReportTo)
+	}
+
+	if p.opt.ReferrerPolicy != "" {
+		c.Response.Header.Set(referrerPolicyHeader, p.opt.ReferrerPolicy)
+	}
+
+	if p.opt.FeaturePolicy != "" {
+		c.Response.Header.Set(featurePolicyHeader, p.opt.FeaturePolicy)
+	}
+
+	if p.opt.IENoOpen {
+		c.Response.Header.Set(ieNoOpenHeader, ieNoOpenValue)
+	}
+
+	return ctx, true
+}
+
+// isIPV4 reports whether host (optionally including a ":port" suffix) is a
+// literal IPv4 address, as opposed to a hostname.
+func isIPV4(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.To4() != nil
+}
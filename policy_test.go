@@ -26,6 +26,7 @@
 package secure
 
 import (
+	"bytes"
 	"context"
 	"net/http"
 	"testing"
@@ -415,6 +416,212 @@ func TestIsIpv4Host(t *testing.T) {
 	assert.DeepEqual(t, isIPV4("example.com:8080"), false)
 }
 
+func TestCORSPreflightAllowedExactOrigin(t *testing.T) {
+	router := newServer(Config{
+		CORS: &CORSConfig{
+			AllowedOrigins: []string{"https://example.com"},
+			AllowedMethods: []string{"GET", "POST"},
+		},
+	})
+
+	w := ut.PerformRequest(router, consts.MethodOptions, "http://www.example.com/foo", nil,
+		ut.Header{Key: "Origin", Value: "https://example.com"},
+		ut.Header{Key: "Access-Control-Request-Method", Value: "POST"},
+	)
+	result := w.Result()
+
+	assert.DeepEqual(t, http.StatusNoContent, result.StatusCode())
+	assert.DeepEqual(t, "https://example.com", result.Header.Get("Access-Control-Allow-Origin"))
+	assert.DeepEqual(t, "Origin", result.Header.Get("Vary"))
+	assert.DeepEqual(t, "GET, POST", result.Header.Get("Access-Control-Allow-Methods"))
+}
+
+func TestCORSPreflightRejectsDisallowedOrigin(t *testing.T) {
+	router := newServer(Config{
+		CORS: &CORSConfig{
+			AllowedOrigins: []string{"https://example.com"},
+		},
+	})
+
+	w := ut.PerformRequest(router, consts.MethodOptions, "http://www.example.com/foo", nil,
+		ut.Header{Key: "Origin", Value: "https://evil.example.com"},
+		ut.Header{Key: "Access-Control-Request-Method", Value: "GET"},
+	)
+	result := w.Result()
+
+	assert.DeepEqual(t, http.StatusForbidden, result.StatusCode())
+}
+
+func TestCORSWildcardSubdomainOrigin(t *testing.T) {
+	router := newServer(Config{
+		CORS: &CORSConfig{
+			AllowedOrigins: []string{"https://*.example.com"},
+		},
+	})
+
+	w := ut.PerformRequest(router, consts.MethodOptions, "http://www.example.com/foo", nil,
+		ut.Header{Key: "Origin", Value: "https://sub.example.com"},
+		ut.Header{Key: "Access-Control-Request-Method", Value: "GET"},
+	)
+	result := w.Result()
+
+	assert.DeepEqual(t, http.StatusNoContent, result.StatusCode())
+	assert.DeepEqual(t, "https://sub.example.com", result.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSWildcardSubdomainOriginRejectsLookalike(t *testing.T) {
+	router := newServer(Config{
+		CORS: &CORSConfig{
+			AllowedOrigins: []string{"https://*.example.com"},
+		},
+	})
+
+	// "https://evil-example.com" must not match "https://*.example.com" -
+	// the host doesn't end in ".example.com", only in "example.com".
+	w := ut.PerformRequest(router, consts.MethodOptions, "http://www.example.com/foo", nil,
+		ut.Header{Key: "Origin", Value: "https://evil-example.com"},
+		ut.Header{Key: "Access-Control-Request-Method", Value: "GET"},
+	)
+	result := w.Result()
+
+	assert.DeepEqual(t, http.StatusForbidden, result.StatusCode())
+}
+
+func TestCORSRegexOrigin(t *testing.T) {
+	router := newServer(Config{
+		CORS: &CORSConfig{
+			AllowOriginRegex: `^https://[a-z0-9-]+\.example\.com$`,
+		},
+	})
+
+	w := ut.PerformRequest(router, consts.MethodOptions, "http://www.example.com/foo", nil,
+		ut.Header{Key: "Origin", Value: "https://sub.example.com"},
+		ut.Header{Key: "Access-Control-Request-Method", Value: "GET"},
+	)
+	result := w.Result()
+
+	assert.DeepEqual(t, http.StatusNoContent, result.StatusCode())
+	assert.DeepEqual(t, "https://sub.example.com", result.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSActualResponseAddsVaryAndAllowOrigin(t *testing.T) {
+	router := newServer(Config{
+		CORS: &CORSConfig{
+			AllowedOrigins: []string{"https://example.com"},
+		},
+	})
+
+	w := ut.PerformRequest(router, consts.MethodGet, "http://www.example.com/foo", nil,
+		ut.Header{Key: "Origin", Value: "https://example.com"},
+	)
+	result := w.Result()
+
+	assert.DeepEqual(t, http.StatusOK, result.StatusCode())
+	assert.DeepEqual(t, "https://example.com", result.Header.Get("Access-Control-Allow-Origin"))
+	assert.DeepEqual(t, "Origin", result.Header.Get("Vary"))
+}
+
+func TestCORSWildcardWithCredentialsReflectsConcreteOrigin(t *testing.T) {
+	router := newServer(Config{
+		CORS: &CORSConfig{
+			AllowedOrigins:   []string{"*"},
+			AllowCredentials: true,
+		},
+	})
+
+	w := ut.PerformRequest(router, consts.MethodGet, "http://www.example.com/foo", nil,
+		ut.Header{Key: "Origin", Value: "https://example.com"},
+	)
+	result := w.Result()
+
+	// "*" can't be combined with Access-Control-Allow-Credentials: true, so
+	// the concrete origin must be reflected back instead, with Vary: Origin.
+	assert.DeepEqual(t, http.StatusOK, result.StatusCode())
+	assert.DeepEqual(t, "https://example.com", result.Header.Get("Access-Control-Allow-Origin"))
+	assert.DeepEqual(t, "true", result.Header.Get("Access-Control-Allow-Credentials"))
+	assert.DeepEqual(t, "Origin", result.Header.Get("Vary"))
+}
+
+func TestCORSWildcardWithoutCredentialsReturnsWildcard(t *testing.T) {
+	router := newServer(Config{
+		CORS: &CORSConfig{
+			AllowedOrigins: []string{"*"},
+		},
+	})
+
+	w := ut.PerformRequest(router, consts.MethodGet, "http://www.example.com/foo", nil,
+		ut.Header{Key: "Origin", Value: "https://example.com"},
+	)
+	result := w.Result()
+
+	assert.DeepEqual(t, http.StatusOK, result.StatusCode())
+	assert.DeepEqual(t, "*", result.Header.Get("Access-Control-Allow-Origin"))
+	assert.DeepEqual(t, "", result.Header.Get("Vary"))
+}
+
+func TestReportURIHandlerLegacyFormat(t *testing.T) {
+	var got *ReportPayload
+	engine := route.NewEngine(config.NewOptions([]config.Option{}))
+	engine.POST("/csp-report", ReportURIHandler(func(_ context.Context, r *ReportPayload) {
+		got = r
+	}))
+
+	body := []byte(`{"csp-report":{"document-uri":"https://example.com/","violated-directive":"script-src","blocked-uri":"https://evil.example/x.js"}}`)
+	w := ut.PerformRequest(engine, consts.MethodPost, "/csp-report", &ut.Body{Body: bytes.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/csp-report"},
+	)
+	result := w.Result()
+
+	assert.DeepEqual(t, http.StatusNoContent, result.StatusCode())
+	assert.DeepEqual(t, "https://example.com/", got.DocumentURI)
+	assert.DeepEqual(t, "script-src", got.ViolatedDirective)
+	assert.DeepEqual(t, "https://evil.example/x.js", got.BlockedURI)
+}
+
+func TestReportURIHandlerReportingAPIFormat(t *testing.T) {
+	var got []*ReportPayload
+	engine := route.NewEngine(config.NewOptions([]config.Option{}))
+	engine.POST("/csp-report", ReportURIHandler(func(_ context.Context, r *ReportPayload) {
+		got = append(got, r)
+	}))
+
+	body := []byte(`[{"type":"csp-violation","body":{"documentURL":"https://example.com/","violatedDirective":"script-src","blockedURL":"https://evil.example/x.js"}}]`)
+	w := ut.PerformRequest(engine, consts.MethodPost, "/csp-report", &ut.Body{Body: bytes.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/reports+json"},
+	)
+	result := w.Result()
+
+	assert.DeepEqual(t, http.StatusNoContent, result.StatusCode())
+	assert.DeepEqual(t, 1, len(got))
+	assert.DeepEqual(t, "https://example.com/", got[0].DocumentURI)
+	assert.DeepEqual(t, "script-src", got[0].ViolatedDirective)
+}
+
+func TestWithReportURIInterceptsConfiguredPath(t *testing.T) {
+	var got *ReportPayload
+	engine := route.NewEngine(config.NewOptions([]config.Option{}))
+	engine.Use(New(Config{
+		ContentSecurityPolicyReportOnly: "default-src 'self'",
+		ReportTo:                        "/csp-report",
+		ReportURIPath:                   "/csp-report",
+		ReportURICallback: func(_ context.Context, r *ReportPayload) {
+			got = r
+		},
+	}))
+	engine.POST("/csp-report", func(_ context.Context, c *app.RequestContext) {
+		c.String(200, testResponse)
+	})
+
+	body := []byte(`{"csp-report":{"document-uri":"https://example.com/","violated-directive":"script-src"}}`)
+	w := ut.PerformRequest(engine, consts.MethodPost, "/csp-report", &ut.Body{Body: bytes.NewReader(body), Len: len(body)},
+		ut.Header{Key: "Content-Type", Value: "application/csp-report"},
+	)
+	result := w.Result()
+
+	assert.DeepEqual(t, http.StatusNoContent, result.StatusCode())
+	assert.DeepEqual(t, "script-src", got.ViolatedDirective)
+}
+
 func performRequest(engine *route.Engine, url string, header ...ut.Header) *protocol.Response {
 	return ut.PerformRequest(engine, consts.MethodGet, url, nil).Result()
 }
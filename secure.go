@@ -7,185 +7,314 @@ import (
 )
 
 type (
-	Option func(o *options)
+	// Option mutates a Config while it is being assembled by Default. Each
+	// WithXxx helper below sets the field it is named after, except
+	// WithReportURI, which sets ReportURIPath and ReportURICallback.
+	Option func(c *Config)
 
-	// options is a struct for specifying configuration options for the secure.
-	options struct {
+	// Config is a struct for specifying configuration options for the secure
+	// middleware. Build one directly and pass it to New, or start from
+	// DefaultConfig and layer WithXxx options on top via Default.
+	Config struct {
 		// AllowedHosts is a list of fully qualified domain names that are allowed.
 		// Default is empty list, which allows any and all host names.
-		allowedHosts []string
-		// If WithSSLRedirect is set to true, then only allow https requests.
+		AllowedHosts []string
+		// If SSLRedirect is set to true, then only allow https requests.
 		// Default is false.
-		WithSSLRedirect bool
+		SSLRedirect bool
 		// If SSLTemporaryRedirect is true, the a 302 will be used while redirecting.
 		// Default is false (301).
-		sslTemporaryRedirect bool
+		SSLTemporaryRedirect bool
 		// SSLHost is the host name that is used to redirect http requests to https.
 		// Default is "", which indicates to use the same host.
-		sslHost string
+		SSLHost string
+		// If SSLForceHost is true and SSLHost is set, then requests that already
+		// arrive over HTTPS but on the wrong host are redirected to SSLHost too.
+		// Default is false.
+		SSLForceHost bool
 		// STSSeconds is the max-age of the Strict-Transport-Security header.
 		// Default is 0, which would NOT include the header.
-		stsSeconds int64
+		STSSeconds int64
 		// If STSIncludeSubdomains is set to true, the `includeSubdomains` will
 		// be appended to the Strict-Transport-Security header. Default is false.
-		stsIncludeSubdomains bool
+		STSIncludeSubdomains bool
+		// If STSPreload is set to true, the `preload` directive will be
+		// appended to the Strict-Transport-Security header, as required for
+		// submission to the HSTS preload list. Default is false.
+		STSPreload bool
+		// ForceSTSHeader is kept for API compatibility with unrolled/secure.
+		// The Strict-Transport-Security header is always added once
+		// STSSeconds is set (see applyToContext), so this no longer changes
+		// behavior; it's safe to leave unset.
+		ForceSTSHeader bool
 		// If FrameDeny is set to true, adds the X-Frame-Options header with
 		// the value of `DENY`. Default is false.
-		frameDeny bool
+		FrameDeny bool
 		// CustomFrameOptionsValue allows the X-Frame-Options header value
 		// to be set with a custom value. This overrides the FrameDeny option.
-		customFrameOptionsValue string
+		CustomFrameOptionsValue string
 		// If ContentTypeNosniff is true, adds the X-Content-Type-Options header
 		// with the value `nosniff`. Default is false.
-		contentTypeNosniff bool
+		ContentTypeNosniff bool
 		// If BrowserXssFilter is true, adds the X-XSS-Protection header with
 		// the value `1; mode=block`. Default is false.
-		browserXssFilter bool
+		BrowserXssFilter bool
 		// ContentSecurityPolicy allows the Content-Security-Policy header value
 		// to be set with a custom value. Default is "".
-		contentSecurityPolicy string
+		ContentSecurityPolicy string
+		// If ContentSecurityPolicyNonce is true, a fresh nonce is generated
+		// for every request, substituted into any "$NONCE" placeholder in
+		// ContentSecurityPolicy, and made available via CSPNonce. Default
+		// is false.
+		ContentSecurityPolicyNonce bool
+		// ContentSecurityPolicyReportOnly allows rolling out a
+		// Content-Security-Policy via Content-Security-Policy-Report-Only,
+		// independently of (or alongside) ContentSecurityPolicy. Default is "".
+		ContentSecurityPolicyReportOnly string
+		// ReportTo is the Report-To header value, typically pointing at
+		// ReportURIPath. Default is "", which would NOT include the header.
+		ReportTo string
+		// ReportURIPath, when non-empty, turns this middleware into the CSP
+		// violation-reporting endpoint for that path: POST requests to it
+		// are parsed with the same legacy application/csp-report /
+		// application/reports+json logic as ReportURIHandler and
+		// short-circuited with a 204 before any security header is written.
+		// Typically paired with ReportTo so the configured policy points
+		// browsers back at it.
+		ReportURIPath string
+		// ReportURICallback is invoked once per violation report parsed at
+		// ReportURIPath. Required when ReportURIPath is set.
+		ReportURICallback func(context.Context, *ReportPayload)
 		// HTTP header "Referrer-Policy" governs which referrer information, sent in the Referrer header, should be included with requests made.
-		referrerPolicy string
+		ReferrerPolicy string
 		// When true, the whole security policy applied by the middleware is disabled completely.
-		isDevelopment bool
-		// Handlers for when an error occurs (ie bad host).
-		badHostHandler app.HandlerFunc
-		// Prevent Internet Explorer from executing downloads in your site’s context
-		ieNoOpen bool
-		// Feature Policy is a new header that allows a site to control which features and APIs can be used in the browser.
-		featurePolicy string
+		IsDevelopment bool
+		// BadHostHandler is the handler invoked when an error occurs (ie bad host).
+		BadHostHandler app.HandlerFunc
+		// IENoOpen prevents Internet Explorer from executing downloads in your site’s context
+		IENoOpen bool
+		// FeaturePolicy is a new header that allows a site to control which features and APIs can be used in the browser.
+		FeaturePolicy string
 		// If DontRedirectIPV4Hostnames is true, requests to hostnames that are IPV4
 		// addresses aren't redirected. This is to allow load balancer health checks
 		// to succeed.
-		dontRedirectIPV4Hostnames bool
+		DontRedirectIPV4Hostnames bool
 
 		// If the request is insecure, treat it as secure if any of the headers in this dict are set to their corresponding value
 		// This is useful when your app is running behind a secure proxy that forwards requests to your app over http (such as on Heroku).
-		sslProxyHeaders map[string]string
+		SSLProxyHeaders map[string]string
+
+		// CORS holds the Cross-Origin Resource Sharing configuration. A nil
+		// value (the default) disables CORS handling entirely.
+		CORS *CORSConfig
+
+		// PublicKey is the Public-Key-Pins header value. Default is "",
+		// which would NOT include the header.
+		PublicKey string
+		// ExpectCT is the Expect-CT header value. Default is "", which
+		// would NOT include the header.
+		ExpectCT string
+
+		// CustomRequestHeaders are injected into the inbound request before
+		// c.Next is called. An empty value deletes that header instead.
+		CustomRequestHeaders map[string]string
+		// CustomResponseHeaders are injected into the outbound response
+		// after c.Next returns. An empty value deletes that header instead.
+		CustomResponseHeaders map[string]string
 	}
 )
 
 // WithAllowedHosts is a list of fully qualified domain names that are allowed.
 // Default is empty list, which allows any and all host names.
 func WithAllowedHosts(ss []string) Option {
-	return func(o *options) {
-		o.allowedHosts = ss
+	return func(c *Config) {
+		c.AllowedHosts = ss
 	}
 }
 
 // WithSSLRedirect when WithSSLRedirect is set to true, then only allow https requests.
 // Default is false.
 func WithSSLRedirect(b bool) Option {
-	return func(o *options) {
-		o.WithSSLRedirect = b
+	return func(c *Config) {
+		c.SSLRedirect = b
 	}
 }
 
 // WithSSLTemporaryRedirect when SSLTemporaryRedirect is true, the a 302 will be used while redirecting.
 // Default is false (301).
 func WithSSLTemporaryRedirect(b bool) Option {
-	return func(o *options) {
-		o.sslTemporaryRedirect = b
+	return func(c *Config) {
+		c.SSLTemporaryRedirect = b
 	}
 }
 
 // WithSSLHost is the host name that is used to redirect http requests to https.
 // Default is "", which indicates to use the same host.
 func WithSSLHost(s string) Option {
-	return func(o *options) {
-		o.sslHost = s
+	return func(c *Config) {
+		c.SSLHost = s
+	}
+}
+
+// WithSSLForceHost when true, combined with WithSSLHost, also redirects
+// requests that already arrive over HTTPS but on a host other than SSLHost
+// to the canonical host. Default is false.
+func WithSSLForceHost(b bool) Option {
+	return func(c *Config) {
+		c.SSLForceHost = b
 	}
 }
 
 // WithSTSSecond is the max-age of the Strict-Transport-Security header.
 // Default is 0, which would NOT include the header.
 func WithSTSSecond(sec int64) Option {
-	return func(o *options) {
-		o.stsSeconds = sec
+	return func(c *Config) {
+		c.STSSeconds = sec
 	}
 }
 
 // WithSTSIncludeSubdomains when STSIncludeSubdomains is set to true, the `includeSubdomains` will
 // be appended to the Strict-Transport-Security header. Default is false.
 func WithSTSIncludeSubdomains(b bool) Option {
-	return func(o *options) {
-		o.stsIncludeSubdomains = b
+	return func(c *Config) {
+		c.STSIncludeSubdomains = b
+	}
+}
+
+// WithSTSPreload when STSPreload is set to true, the `preload` directive will
+// be appended to the Strict-Transport-Security header, as required for
+// submission to the HSTS preload list. Default is false.
+func WithSTSPreload(b bool) Option {
+	return func(c *Config) {
+		c.STSPreload = b
+	}
+}
+
+// WithForceSTSHeader is kept for API compatibility; the
+// Strict-Transport-Security header is always emitted once STSSeconds is
+// set, regardless of scheme, so this no longer changes behavior.
+func WithForceSTSHeader(b bool) Option {
+	return func(c *Config) {
+		c.ForceSTSHeader = b
 	}
 }
 
 // WithFrameDeny when FrameDeny is set to true, adds the X-Frame-Options header with
 // the value of `DENY`. Default is false.
 func WithFrameDeny(b bool) Option {
-	return func(o *options) {
-		o.frameDeny = b
+	return func(c *Config) {
+		c.FrameDeny = b
 	}
 }
 
 // WithCustomFrameOptionsValue allows the X-Frame-Options header value
 // to be set with a custom value. This overrides the FrameDeny option.
 func WithCustomFrameOptionsValue(s string) Option {
-	return func(o *options) {
-		o.customFrameOptionsValue = s
+	return func(c *Config) {
+		c.CustomFrameOptionsValue = s
 	}
 }
 
 // WithContentTypeNosniff when ContentTypeNosniff is true, adds the X-Content-Type-Options header
 // with the value `nosniff`. Default is false.
 func WithContentTypeNosniff(b bool) Option {
-	return func(o *options) {
-		o.contentTypeNosniff = b
+	return func(c *Config) {
+		c.ContentTypeNosniff = b
 	}
 }
 
 // WithBrowserXssFilter when BrowserXssFilter is true, adds the X-XSS-Protection header with
 // the value `1; mode=block`. Default is false.
 func WithBrowserXssFilter(b bool) Option {
-	return func(o *options) {
-		o.browserXssFilter = b
+	return func(c *Config) {
+		c.BrowserXssFilter = b
 	}
 }
 
 // WithContentSecurityPolicy  allows the Content-Security-Policy header value
 // to be set with a custom value. Default is "".
 func WithContentSecurityPolicy(s string) Option {
-	return func(o *options) {
-		o.contentSecurityPolicy = s
+	return func(c *Config) {
+		c.ContentSecurityPolicy = s
+	}
+}
+
+// WithContentSecurityPolicyNonce when true, generates a fresh cryptographically
+// random nonce for every request, substitutes it into any "$NONCE" placeholder
+// inside the configured ContentSecurityPolicy, and exposes it via CSPNonce so
+// handlers/templates can add nonce="..." attributes to inline scripts/styles.
+func WithContentSecurityPolicyNonce(b bool) Option {
+	return func(c *Config) {
+		c.ContentSecurityPolicyNonce = b
+	}
+}
+
+// WithContentSecurityPolicyReportOnly emits the given policy under
+// Content-Security-Policy-Report-Only instead of (or alongside) the
+// enforcing Content-Security-Policy header, letting violations be observed
+// without blocking anything. Combine with WithReportURI and WithReportTo
+// to collect the resulting reports.
+func WithContentSecurityPolicyReportOnly(s string) Option {
+	return func(c *Config) {
+		c.ContentSecurityPolicyReportOnly = s
+	}
+}
+
+// WithReportTo sets the Report-To header value, typically pointing at the
+// path registered with WithReportURI.
+func WithReportTo(s string) Option {
+	return func(c *Config) {
+		c.ReportTo = s
+	}
+}
+
+// WithReportURI registers path as this middleware's CSP violation-reporting
+// endpoint. POST requests to path are parsed with the same legacy
+// application/csp-report / application/reports+json logic as
+// ReportURIHandler, decoded into cb once per reported violation, and
+// short-circuited with a 204 before any other security-header logic runs.
+// Typically paired with WithReportTo so the configured policy points
+// browsers back at path.
+func WithReportURI(path string, cb func(context.Context, *ReportPayload)) Option {
+	return func(c *Config) {
+		c.ReportURIPath = path
+		c.ReportURICallback = cb
 	}
 }
 
 // WithReferrerPolicy use to set HTTP header "Referrer-Policy" governs which referrer information,
 // sent in the Referrer header,/should be included with requests made.
 func WithReferrerPolicy(s string) Option {
-	return func(o *options) {
-		o.referrerPolicy = s
+	return func(c *Config) {
+		c.ReferrerPolicy = s
 	}
 }
 
 // WithIsDevelopment when true, the whole security policy applied by the middleware is disabled completely.
 func WithIsDevelopment(b bool) Option {
-	return func(o *options) {
-		o.isDevelopment = b
+	return func(c *Config) {
+		c.IsDevelopment = b
 	}
 }
 
 // WithIENoOpen prevents Internet Explorer from executing downloads in your site’s context
 func WithIENoOpen(b bool) Option {
-	return func(o *options) {
-		o.ieNoOpen = b
+	return func(c *Config) {
+		c.IENoOpen = b
 	}
 }
 
 // WithBadHostHandler use to when an error occurs (ie bad host).
 func WithBadHostHandler(handler app.HandlerFunc) Option {
-	return func(o *options) {
-		o.badHostHandler = handler
+	return func(c *Config) {
+		c.BadHostHandler = handler
 	}
 }
 
 // WithFeaturePolicy  is a new header that allows a site to control which features and APIs can be used in the browser.
 func WithFeaturePolicy(s string) Option {
-	return func(o *options) {
-		o.featurePolicy = s
+	return func(c *Config) {
+		c.FeaturePolicy = s
 	}
 }
 
@@ -193,61 +322,118 @@ func WithFeaturePolicy(s string) Option {
 // addresses aren't redirected. This is to allow load balancer health checks
 // to succeed.
 func WithDontRedirectIPV4Hostnames(b bool) Option {
-	return func(o *options) {
-		o.dontRedirectIPV4Hostnames = b
+	return func(c *Config) {
+		c.DontRedirectIPV4Hostnames = b
 	}
 }
 
 // WithSSLProxyHeaders If the request is insecure, treat it as secure if any of the headers in this dict are set to their corresponding value
 // This is useful when your app is running behind a secure proxy that forwards requests to your app over http (such as on Heroku).
 func WithSSLProxyHeaders(m map[string]string) Option {
-	return func(o *options) {
-		o.sslProxyHeaders = m
+	return func(c *Config) {
+		c.SSLProxyHeaders = m
+	}
+}
+
+// WithCustomRequestHeaders injects the given headers into the inbound
+// request, visible to downstream handlers via c.Request.Header. A header
+// mapped to an empty value is deleted from the request instead of set.
+func WithCustomRequestHeaders(m map[string]string) Option {
+	return func(c *Config) {
+		c.CustomRequestHeaders = m
+	}
+}
+
+// WithCustomResponseHeaders injects the given headers into the outbound
+// response. A header mapped to an empty value is deleted from the
+// response instead of set.
+func WithCustomResponseHeaders(m map[string]string) Option {
+	return func(c *Config) {
+		c.CustomResponseHeaders = m
+	}
+}
+
+// WithCORS enables Cross-Origin Resource Sharing handling using the given
+// CORSConfig: preflight OPTIONS requests are answered directly and the
+// relevant Access-Control-* headers are added to actual responses whose
+// Origin is allowed. Default is disabled.
+func WithCORS(cfg CORSConfig) Option {
+	return func(c *Config) {
+		c.CORS = &cfg
 	}
 }
 
-// Default returns a Configuration with strict security settings.
+// DefaultConfig returns a Config with strict security settings:
 // ```
-//		WithSSLRedirect:           true
-//		IsDevelopment:         false
-//		STSSeconds:            315360000
-//		STSIncludeSubdomains:  true
-//		FrameDeny:             true
-//		ContentTypeNosniff:    true
-//		BrowserXssFilter:      true
-//		ContentSecurityPolicy: "default-src 'self'"
-//		SSLProxyHeaders:       map[string]string{"X-Forwarded-Proto": "https"},
+//
+//	SSLRedirect:           true
+//	IsDevelopment:         false
+//	STSSeconds:            315360000
+//	FrameDeny:             true
+//	ContentTypeNosniff:    true
+//	BrowserXssFilter:      true
+//	ContentSecurityPolicy: "default-src 'self'"
+//	IENoOpen:              true
+//	SSLProxyHeaders:       map[string]string{"X-Forwarded-Proto": "https"},
+//
 // ```
+func DefaultConfig() Config {
+	return Config{
+		SSLRedirect:           true,
+		IsDevelopment:         false,
+		STSSeconds:            315360000,
+		FrameDeny:             true,
+		ContentTypeNosniff:    true,
+		BrowserXssFilter:      true,
+		ContentSecurityPolicy: "default-src 'self'",
+		IENoOpen:              true,
+		SSLProxyHeaders:       map[string]string{"X-Forwarded-Proto": "https"},
+	}
+}
+
+// Default returns a handler built from DefaultConfig with any additional
+// opts layered on top.
 func Default(opts ...Option) app.HandlerFunc {
-	options := []Option{
-		WithSSLRedirect(true),
-		WithIsDevelopment(false),
-		WithSTSSecond(315360000),
-		WithFrameDeny(true),
-		WithContentTypeNosniff(true),
-		WithBrowserXssFilter(true),
-		WithContentSecurityPolicy("default-src 'self'"),
-		WithIENoOpen(true),
-		WithSSLProxyHeaders(map[string]string{"X-Forwarded-Proto": "https"}),
-	}
-	options = append(options, opts...)
-	return New(options...)
-}
-
-func (o *options) Apply(opts []Option) {
+	cfg := DefaultConfig()
 	for _, opt := range opts {
-		opt(o)
+		opt(&cfg)
 	}
+	return New(cfg)
 }
 
 // New creates an instance of the secure middleware using the specified configuration.
-// router.Use(secure.N)
-func New(opts ...Option) app.HandlerFunc {
-	policy := newPolicy(opts)
+// router.Use(secure.New(cfg))
+func New(cfg Config) app.HandlerFunc {
+	policy := newPolicy(cfg)
 	return func(ctx context.Context, c *app.RequestContext) {
-		if !policy.applyToContext(ctx, c) {
+		if len(policy.opt.CustomRequestHeaders) > 0 {
+			policy.applyCustomRequestHeaders(c)
+		}
+
+		// The violation-reporting endpoint, like CORS preflight below, is
+		// answered before anything else so it works regardless of
+		// SSLRedirect/AllowedHosts.
+		if policy.opt.ReportURIPath != "" && policy.handleReportURI(ctx, c) {
+			return
+		}
+
+		// CORS preflight is answered before any of the SSL/host checks so
+		// that it always succeeds even when SSLRedirect or AllowedHosts
+		// would otherwise reject/redirect the request.
+		if policy.opt.CORS != nil && policy.handleCORSPreflight(c) {
 			return
 		}
+		ctx, ok := policy.applyToContext(ctx, c)
+		if !ok {
+			return
+		}
+		if policy.opt.CORS != nil {
+			policy.applyCORSHeaders(c)
+		}
 		c.Next(ctx)
+
+		if len(policy.opt.CustomResponseHeaders) > 0 {
+			policy.applyCustomResponseHeaders(c)
+		}
 	}
 }